@@ -0,0 +1,117 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"go.opentelemetry.io/collector/config/configparser"
+	"go.opentelemetry.io/collector/service/parserprovider"
+)
+
+const (
+	memoryBallastExtensionName = "memory_ballast"
+	memoryLimiterProcessorName = "memory_limiter"
+)
+
+// newBallastParserProvider wraps base so that, once the configuration has
+// been parsed, a memory_ballast extension sized to ballastSizeMiB is present
+// (adding it to service::extensions if it isn't already there) and any
+// memory_limiter processors have their ballast_size_mib reconciled with the
+// same value. This replaces the deprecated --mem-ballast-size-mib flag and
+// guarantees the ballast extension and the memory limiter always agree on
+// the ballast size.
+func newBallastParserProvider(base parserprovider.ParserProvider, ballastSizeMiB int) parserprovider.ParserProvider {
+	return &ballastParserProvider{base: base, ballastSizeMiB: ballastSizeMiB}
+}
+
+type ballastParserProvider struct {
+	base           parserprovider.ParserProvider
+	ballastSizeMiB int
+}
+
+func (b *ballastParserProvider) Get() (*configparser.ConfigMap, error) {
+	cfgMap, err := b.base.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyMemoryBallast(cfgMap, b.ballastSizeMiB); err != nil {
+		return nil, err
+	}
+
+	return cfgMap, nil
+}
+
+// applyMemoryBallast injects or reconciles the memory_ballast extension and
+// any memory_limiter processors in cfgMap with ballastSizeMiB. The
+// memory_ballast size_mib is always overridden to the resolved value; the
+// memory_limiter cross-check in reconcileMemoryLimiters is where a
+// conflicting user-specified value is treated as an error.
+func applyMemoryBallast(cfgMap *configparser.ConfigMap, ballastSizeMiB int) error {
+	sizeMibKey := fmt.Sprintf("extensions::%s::size_mib", memoryBallastExtensionName)
+	cfgMap.Set(sizeMibKey, ballastSizeMiB)
+
+	addServiceExtension(cfgMap, memoryBallastExtensionName)
+
+	return reconcileMemoryLimiters(cfgMap, ballastSizeMiB)
+}
+
+// addServiceExtension appends name to service::extensions if it isn't
+// already present.
+func addServiceExtension(cfgMap *configparser.ConfigMap, name string) {
+	extensions, _ := cfgMap.Get("service::extensions").([]interface{})
+	for _, e := range extensions {
+		if fmt.Sprintf("%v", e) == name {
+			return
+		}
+	}
+	cfgMap.Set("service::extensions", append(extensions, name))
+}
+
+// reconcileMemoryLimiters walks every memory_limiter processor (including
+// named instances such as memory_limiter/with-spike, see isMemoryLimiter) and
+// fills in ballast_size_mib from ballastSizeMiB when it is unset, or errors
+// out if the user already configured a conflicting value.
+func reconcileMemoryLimiters(cfgMap *configparser.ConfigMap, ballastSizeMiB int) error {
+	processors, _ := cfgMap.Get("processors").(map[string]interface{})
+	for name := range processors {
+		if !isMemoryLimiter(name) {
+			continue
+		}
+
+		key := fmt.Sprintf("processors::%s::ballast_size_mib", name)
+		switch existing := cfgMap.Get(key).(type) {
+		case nil:
+			cfgMap.Set(key, ballastSizeMiB)
+		case int:
+			if existing != ballastSizeMiB {
+				return fmt.Errorf("processors::%s::ballast_size_mib (%d) conflicts with resolved ballast size (%d)", name, existing, ballastSizeMiB)
+			}
+		default:
+			log.Printf("Unexpected type for %s, leaving as configured", key)
+		}
+	}
+	return nil
+}
+
+// isMemoryLimiter reports whether name is the memory_limiter processor or
+// one of its named instances, e.g. "memory_limiter/with-spike".
+func isMemoryLimiter(name string) bool {
+	return name == memoryLimiterProcessorName || strings.HasPrefix(name, memoryLimiterProcessorName+"/")
+}
@@ -0,0 +1,93 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"go.opentelemetry.io/collector/config/configparser"
+)
+
+func TestAddServiceExtension(t *testing.T) {
+	cfgMap := configparser.NewConfigMap()
+
+	addServiceExtension(cfgMap, memoryBallastExtensionName)
+	addServiceExtension(cfgMap, memoryBallastExtensionName)
+
+	extensions, _ := cfgMap.Get("service::extensions").([]interface{})
+	if len(extensions) != 1 {
+		t.Fatalf("service::extensions = %v, want exactly one entry after adding the same extension twice", extensions)
+	}
+	if extensions[0] != memoryBallastExtensionName {
+		t.Fatalf("service::extensions[0] = %v, want %q", extensions[0], memoryBallastExtensionName)
+	}
+}
+
+func TestReconcileMemoryLimitersFillsUnset(t *testing.T) {
+	cfgMap, err := configparser.NewConfigMapFromBuffer(bytes.NewBufferString(
+		"processors:\n  memory_limiter:\n  memory_limiter/with-spike:\n  batch:\n"))
+	if err != nil {
+		t.Fatalf("failed to build fixture: %v", err)
+	}
+
+	if err := reconcileMemoryLimiters(cfgMap, 256); err != nil {
+		t.Fatalf("reconcileMemoryLimiters() returned an error: %v", err)
+	}
+
+	if got := cfgMap.Get("processors::memory_limiter::ballast_size_mib"); got != 256 {
+		t.Fatalf("processors::memory_limiter::ballast_size_mib = %v, want 256", got)
+	}
+	if got := cfgMap.Get("processors::memory_limiter/with-spike::ballast_size_mib"); got != 256 {
+		t.Fatalf("processors::memory_limiter/with-spike::ballast_size_mib = %v, want 256", got)
+	}
+	if got := cfgMap.Get("processors::batch::ballast_size_mib"); got != nil {
+		t.Fatalf("processors::batch::ballast_size_mib = %v, want nil (batch is not a memory_limiter)", got)
+	}
+}
+
+func TestReconcileMemoryLimitersConflict(t *testing.T) {
+	cfgMap, err := configparser.NewConfigMapFromBuffer(bytes.NewBufferString(
+		"processors:\n  memory_limiter:\n    ballast_size_mib: 123\n"))
+	if err != nil {
+		t.Fatalf("failed to build fixture: %v", err)
+	}
+
+	err = reconcileMemoryLimiters(cfgMap, 256)
+	if err == nil {
+		t.Fatalf("reconcileMemoryLimiters() = nil error, want an error for the conflicting ballast_size_mib")
+	}
+}
+
+func TestIsMemoryLimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "memory_limiter", want: true},
+		{name: "memory_limiter/with-spike", want: true},
+		{name: "batch", want: false},
+		{name: "memory_limiterish", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMemoryLimiter(tt.name); got != tt.want {
+				t.Fatalf("isMemoryLimiter(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
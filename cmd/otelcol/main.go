@@ -18,18 +18,19 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/service"
-	"go.opentelemetry.io/collector/service/parserprovider"
 	"go.uber.org/zap"
 
+	"github.com/shirou/gopsutil/v3/mem"
+
 	"github.com/signalfx/splunk-otel-collector/internal/components"
 	"github.com/signalfx/splunk-otel-collector/internal/configprovider"
 	"github.com/signalfx/splunk-otel-collector/internal/configsources"
@@ -37,13 +38,15 @@ import (
 )
 
 const (
-	ballastEnvVarName     = "SPLUNK_BALLAST_SIZE_MIB"
-	configEnvVarName      = "SPLUNK_CONFIG"
-	configYamlEnvVarName  = "SPLUNK_CONFIG_YAML"
-	memLimitMiBEnvVarName = "SPLUNK_MEMORY_LIMIT_MIB"
-	memTotalEnvVarName    = "SPLUNK_MEMORY_TOTAL_MIB"
-	realmEnvVarName       = "SPLUNK_REALM"
-	tokenEnvVarName       = "SPLUNK_ACCESS_TOKEN"
+	ballastEnvVarName            = "SPLUNK_BALLAST_SIZE_MIB"
+	ballastPercentageEnvVarName  = "SPLUNK_BALLAST_SIZE_PERCENTAGE"
+	configEnvVarName             = "SPLUNK_CONFIG"
+	configYamlEnvVarName         = "SPLUNK_CONFIG_YAML"
+	memLimitMiBEnvVarName        = "SPLUNK_MEMORY_LIMIT_MIB"
+	memLimitPercentageEnvVarName = "SPLUNK_MEMORY_LIMIT_PERCENTAGE"
+	memTotalEnvVarName           = "SPLUNK_MEMORY_TOTAL_MIB"
+	realmEnvVarName              = "SPLUNK_REALM"
+	tokenEnvVarName              = "SPLUNK_ACCESS_TOKEN"
 
 	defaultDockerSAPMConfig        = "/etc/otel/collector/gateway_config.yaml"
 	defaultDockerOTLPConfig        = "/etc/otel/collector/otlp_config_linux.yaml"
@@ -53,14 +56,33 @@ const (
 	defaultMemoryLimitPercentage   = 90
 	defaultMemoryLimitMaxMiB       = 2048
 	defaultMemoryTotalMiB          = 512
+
+	bytesPerMiB = 1024 * 1024
+
+	// cgroupV1UnlimitedThreshold bounds the "no limit configured" sentinel
+	// cgroup v1 reports for memory.limit_in_bytes (commonly
+	// 9223372036854771712, i.e. math.MaxInt64 rounded down to a page
+	// boundary). Any real memory limit stays far below this.
+	cgroupV1UnlimitedThreshold = math.MaxInt64 - (1 << 24)
+
+	// cgroupV1MemLimitPath and cgroupV2MemLimitPath are the well-known
+	// locations of the memory limit applied to the current cgroup under the
+	// v1 and v2 hierarchies, respectively.
+	cgroupV1MemLimitPath = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+	cgroupV2MemLimitPath = "/sys/fs/cgroup/memory.max"
 )
 
 func main() {
 	// TODO: Use same format as the collector
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
+	validateOnly := isValidateCommand(os.Args[1:])
+
+	var configPaths []string
+	var configYAML string
+	var ballastSizeMiB, memLimitMiB int
 	if !contains(os.Args[1:], "-h") && !contains(os.Args[1:], "--help") {
-		checkRuntimeParams()
+		configPaths, configYAML, ballastSizeMiB, memLimitMiB = checkRuntimeParams()
 	}
 
 	factories, err := components.Get()
@@ -73,18 +95,25 @@ func main() {
 		Version: version.Version,
 	}
 
-	baseParserProvider := parserprovider.Default()
-	if configYAML := os.Getenv(configYamlEnvVarName); configYAML != "" && os.Getenv(configEnvVarName) == "" {
-		baseParserProvider = parserprovider.NewInMemory(bytes.NewBufferString(configYAML))
-	}
+	baseParserProvider := newBaseParserProvider(configPaths, configYAML)
 
-	parserProvider := configprovider.NewConfigSourceParserProvider(
-		baseParserProvider,
-		zap.NewNop(), // The service logger is not available yet, setting it to NoP.
-		info,
-		configsources.Get()...,
+	parserProvider := newBallastParserProvider(
+		configprovider.NewConfigSourceParserProvider(
+			baseParserProvider,
+			zap.NewNop(), // The service logger is not available yet, setting it to NoP.
+			info,
+			configsources.Get()...,
+		),
+		ballastSizeMiB,
 	)
 
+	if validateOnly {
+		if err := validate(factories, info, parserProvider, configPaths, ballastSizeMiB, memLimitMiB); err != nil {
+			log.Fatalf("validate: %v", err)
+		}
+		return
+	}
+
 	serviceParams := service.AppSettings{
 		BuildInfo:      info,
 		Factories:      factories,
@@ -111,29 +140,46 @@ func contains(arr []string, str string) bool {
 	return false
 }
 
-// Get the value of a key in an array
-// Support key/value with and with an equal sign
-func getKeyValue(args []string, argName string) string {
-	val := ""
-	for i, arg := range args {
+// Get every value of a key in an array, supporting the flag being repeated
+// and both --key value and --key=value forms.
+func getKeyValues(args []string, argName string) []string {
+	var vals []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch {
 		case strings.HasPrefix(arg, argName+"="):
-			s := strings.Split(arg, "=")
-			val = s[1]
-		case arg == argName:
+			vals = append(vals, strings.SplitN(arg, "=", 2)[1])
+		case arg == argName && i+1 < len(args):
 			i++
-			val = args[i]
+			vals = append(vals, args[i])
 		}
 	}
-	return val
+	return vals
+}
+
+// splitConfigList splits a colon- or comma-separated SPLUNK_CONFIG value
+// into its individual config paths.
+func splitConfigList(val string) []string {
+	if val == "" {
+		return nil
+	}
+	return strings.FieldsFunc(val, func(r rune) bool {
+		return r == ':' || r == ','
+	})
 }
 
 // Check runtime parameters
 // Runtime parameters take priority over environment variables
 // Config and ballast flags are checked
 // Config and all memory env vars are checked
-func checkRuntimeParams() {
-	setConfigSource()
+// Returns the resolved config paths (nil if SPLUNK_CONFIG_YAML is used instead),
+// the SPLUNK_CONFIG_YAML value that should actually be merged in, ballast
+// size, and memory limit in MiB, so the caller can build the parser
+// provider, reconcile the ballast with the memory_ballast extension and
+// memory_limiter processor once the config is parsed, and re-run the same
+// sanity checks statically via the validate subcommand.
+func checkRuntimeParams() ([]string, string, int, int) {
+	configPaths, configYAML := setConfigSource()
 
 	// Set default total memory
 	memTotalSizeMiB := defaultMemoryTotalMiB
@@ -151,103 +197,192 @@ func checkRuntimeParams() {
 			log.Fatalf("Expected a number greater than 99 for %s env variable but got %s", memTotalEnvVarName, memTotalEnvVarVal)
 		}
 		memTotalSizeMiB = val
+	} else if detected, ok := detectTotalMemoryMiB(); ok {
+		// No explicit total was given; auto-detect it from the cgroup memory
+		// limit (when running under a container runtime) or, failing that,
+		// the host's total memory.
+		memTotalSizeMiB = detected
 	}
 
-	// Check if memory ballast flag was passed
-	// If so, ensure memory ballast env var is not set
-	// Then set memory ballast and limit properly
-	ballastSize := getKeyValue(os.Args[1:], "--mem-ballast-size-mib")
-	if ballastSize != "" {
-		if os.Getenv(ballastEnvVarName) != "" {
-			log.Fatalf("Both %v and '--config' were specified, but only one is allowed", ballastEnvVarName)
-		}
-		os.Setenv(ballastEnvVarName, ballastSize)
+	ballastSizeMiB := setMemoryBallast(memTotalSizeMiB)
+	memLimitMiB := setMemoryLimit(memTotalSizeMiB, ballastSizeMiB)
+	return configPaths, configYAML, ballastSizeMiB, memLimitMiB
+}
+
+// detectTotalMemoryMiB attempts to discover the amount of memory available
+// to this process when SPLUNK_MEMORY_TOTAL_MIB is not set. It prefers the
+// cgroup v1/v2 memory limit, since that reflects what a container runtime
+// (Docker, Kubernetes) actually granted the collector, and falls back to the
+// host's total memory.
+func detectTotalMemoryMiB() (int, bool) {
+	if limit, ok := cgroupMemoryLimitMiB(); ok {
+		return limit, true
+	}
+
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, false
 	}
-	setMemoryBallast(memTotalSizeMiB)
-	setMemoryLimit(memTotalSizeMiB)
+	return int(vm.Total / bytesPerMiB), true
 }
 
-// Validate and equate specified config file path flag to the config file path env var
-func setConfigSource() {
-	// Config file path from cmd flag --config.
-	pathFlag := getKeyValue(os.Args[1:], "--config")
-	// Config file path from env var.
-	pathVar := os.Getenv(configEnvVarName)
-	// Config YAML from env var.
-	yamlVar := os.Getenv(configYamlEnvVarName)
+// cgroupMemoryLimitMiB reads the memory limit applied to the current
+// cgroup, checking the cgroup v2 path first and falling back to v1.
+func cgroupMemoryLimitMiB() (int, bool) {
+	return cgroupMemoryLimitMiBFromPaths([]string{cgroupV2MemLimitPath, cgroupV1MemLimitPath})
+}
 
-	// Restricting specifying config file path and config YAML env vars simultaneously.
-	if pathVar != "" && yamlVar != "" {
-		log.Fatalf("Specifying env vars %s and %s simultaneously is not allowed", configEnvVarName, configYamlEnvVarName)
+// cgroupMemoryLimitMiBFromPaths implements cgroupMemoryLimitMiB over an
+// explicit, ordered list of candidate paths, so it can be exercised with
+// fixture files in tests.
+func cgroupMemoryLimitMiBFromPaths(paths []string) (int, bool) {
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		val := strings.TrimSpace(string(raw))
+		if val == "" || val == "max" {
+			// "max" (cgroup v2) or an empty value means no limit is set.
+			continue
+		}
+		limit, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || limit <= 0 || limit >= cgroupV1UnlimitedThreshold {
+			// cgroup v1 reports a huge sentinel (typically
+			// math.MaxInt64 rounded down to a page boundary) rather
+			// than "max" or an error when no limit is configured.
+			continue
+		}
+		return int(limit / bytesPerMiB), true
 	}
+	return 0, false
+}
 
-	if pathFlag == "" && yamlVar != "" {
+// percentageOfTotal parses pct, a percentage in [0, 100] read from the env
+// var named envVarName, and returns that percentage of memTotalSizeMiB.
+func percentageOfTotal(pct, envVarName string, memTotalSizeMiB int) int {
+	val, err := strconv.ParseFloat(pct, 64)
+	if err != nil {
+		log.Fatalf("Expected a number in %s env variable but got %s", envVarName, pct)
+	}
+	if math.IsNaN(val) || val < 0 || val > 100 {
+		log.Fatalf("Expected a number between 0 and 100 for %s env variable but got %s", envVarName, pct)
+	}
+	return int(float64(memTotalSizeMiB) * val / 100)
+}
+
+// Validate and resolve the config file path(s) to use, supporting repeated
+// --config flags and a colon- or comma-separated SPLUNK_CONFIG. Returns the
+// paths in merge order (later paths override earlier ones) and the
+// SPLUNK_CONFIG_YAML value that should be merged in as the final overlay on
+// top of them, documenting the precedence: when --config is given, its paths
+// (in the order given) are used and SPLUNK_CONFIG is ignored; otherwise the
+// SPLUNK_CONFIG paths are merged in order. SPLUNK_CONFIG_YAML -- if set --
+// always applies last, on top of every file.
+func setConfigSource() ([]string, string) {
+	// Config file paths from cmd flag --config (may be repeated).
+	pathFlags := getKeyValues(os.Args[1:], "--config")
+	// Config file paths from env var, colon- or comma-separated.
+	pathVars := splitConfigList(os.Getenv(configEnvVarName))
+	// Config YAML from env var, merged in as the final overlay.
+	yamlVar := os.Getenv(configYamlEnvVarName)
+
+	if len(pathFlags) == 0 && len(pathVars) == 0 && yamlVar != "" {
 		log.Printf("Configuring collector using YAML from env var %s", configYamlEnvVarName)
-		return
+		return nil, yamlVar
 	}
 
-	// Config file path flag `--config` should take priority when running from most contexts.
-	if pathFlag != "" {
-		// Config file path flag takes precedence over config YAML env var.
-		if yamlVar != "" {
-			log.Printf("Both %v and '--config' were specified. Ignoring %q environment variable value and using configuration in %q", configYamlEnvVarName, yamlVar, pathFlag)
-		}
-		// Config file path flag takes precedence over config file path env var.
-		if pathVar != "" && pathVar != pathFlag {
-			log.Printf("Both %v and '--config' were specified. Overriding %q environment variable value with %q for this session", configEnvVarName, pathVar, pathFlag)
+	// Config file path flag(s) `--config` should take priority when running from most contexts.
+	paths := pathVars
+	if len(pathFlags) > 0 {
+		// Config file path flags take precedence over config file path env var.
+		if len(pathVars) > 0 && !equalPaths(pathVars, pathFlags) {
+			log.Printf("Both %v and '--config' were specified. Overriding %q environment variable value with %v for this session", configEnvVarName, pathVars, pathFlags)
 		}
-		// Setting the config file path env var to the config file path flag value.
-		pathVar = pathFlag
-		os.Setenv(configEnvVarName, pathVar)
+		paths = pathFlags
+	}
+	if yamlVar != "" {
+		log.Printf("Both %s and config file(s) %v were specified. Merging %s in as the final overlay", configYamlEnvVarName, paths, configYamlEnvVarName)
+	}
+	// Setting the config file path env var to the resolved config file path(s).
+	if len(paths) > 0 {
+		os.Setenv(configEnvVarName, strings.Join(paths, ","))
 	}
 
 	// Use a default config if no config given; supports Docker and local
-	if pathVar == "" {
-		_, err := os.Stat(defaultDockerSAPMConfig)
-		if err == nil {
-			pathVar = defaultDockerSAPMConfig
+	if len(paths) == 0 {
+		if _, err := os.Stat(defaultDockerSAPMConfig); err == nil {
+			paths = []string{defaultDockerSAPMConfig}
 		}
-		_, err = os.Stat(defaultLocalSAPMConfig)
-		if err == nil {
-			pathVar = defaultLocalSAPMConfig
+		if _, err := os.Stat(defaultLocalSAPMConfig); err == nil {
+			paths = []string{defaultLocalSAPMConfig}
 		}
-		if pathVar == "" {
+		if len(paths) == 0 {
 			log.Fatalf("Unable to find the default configuration file, ensure %s environment variable is set properly", configEnvVarName)
 		}
 	} else {
-		// Check if file exists.
-		_, err := os.Stat(pathVar)
-		if err != nil {
-			log.Fatalf("Unable to find the configuration file (%s) ensure %s environment variable is set properly", pathVar, configEnvVarName)
+		// Check every file exists.
+		for _, p := range paths {
+			if _, err := os.Stat(p); err != nil {
+				log.Fatalf("Unable to find the configuration file (%s) ensure %s environment variable is set properly", p, configEnvVarName)
+			}
 		}
 	}
 
-	switch pathVar {
-	case
-		defaultDockerSAPMConfig,
-		defaultDockerOTLPConfig,
-		defaultLocalSAPMConfig,
-		defaultLocalOTLPConfig:
+	for _, p := range paths {
+		if !isDefaultConfigPath(p) {
+			continue
+		}
 		// The following environment variables are required.
 		// If any are missing stop here.
 		requiredEnvVars := []string{realmEnvVarName, tokenEnvVarName}
 		for _, v := range requiredEnvVars {
 			if len(os.Getenv(v)) == 0 {
 				log.Printf("Usage: %s=12345 %s=us0 %s", tokenEnvVarName, realmEnvVarName, os.Args[0])
-				log.Fatalf("ERROR: Missing required environment variable %s with default config path %s", v, pathVar)
+				log.Fatalf("ERROR: Missing required environment variable %s with default config path %s", v, p)
 			}
 		}
 	}
 
 	if !contains(os.Args[1:], "--config") {
-		// Inject the command line flag that controls the configuration.
-		os.Args = append(os.Args, "--config="+pathVar)
+		// Inject the command line flag(s) that control the configuration.
+		for _, p := range paths {
+			os.Args = append(os.Args, "--config="+p)
+		}
 	}
-	log.Printf("Set config to %v", pathVar)
+	log.Printf("Set config to %v", paths)
+	return paths, yamlVar
 }
 
-// Validate and set the memory ballast
-func setMemoryBallast(memTotalSizeMiB int) {
+// isDefaultConfigPath reports whether path is one of the bundled default
+// configs, which require SPLUNK_REALM/SPLUNK_ACCESS_TOKEN to be set.
+func isDefaultConfigPath(path string) bool {
+	switch path {
+	case defaultDockerSAPMConfig, defaultDockerOTLPConfig, defaultLocalSAPMConfig, defaultLocalOTLPConfig:
+		return true
+	}
+	return false
+}
+
+// equalPaths reports whether a and b contain the same paths in the same order.
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate and resolve the memory ballast size. The ballast is no longer
+// injected as a --mem-ballast-size-mib flag (that flag is deprecated
+// upstream); instead the resolved value is reconciled into the
+// memory_ballast extension and memory_limiter processor by
+// newBallastParserProvider once the config has been parsed.
+func setMemoryBallast(memTotalSizeMiB int) int {
 	// Check if the memory ballast is specified via the env var
 	ballastSize := os.Getenv(ballastEnvVarName)
 	// If so, validate and set properly
@@ -260,46 +395,48 @@ func setMemoryBallast(memTotalSizeMiB int) {
 		if 33 > val {
 			log.Fatalf("Expected a number greater than 33 for %s env variable but got %s", ballastEnvVarName, ballastSize)
 		}
+	} else if pct := os.Getenv(ballastPercentageEnvVarName); pct != "" {
+		ballastSize = strconv.Itoa(percentageOfTotal(pct, ballastPercentageEnvVarName, memTotalSizeMiB))
+		os.Setenv(ballastEnvVarName, ballastSize)
 	} else {
 		ballastSize = strconv.Itoa(memTotalSizeMiB * defaultMemoryBallastPercentage / 100)
 		os.Setenv(ballastEnvVarName, ballastSize)
 	}
 
-	args := os.Args[1:]
-	if !contains(args, "--mem-ballast-size-mib") {
-		// Inject the command line flag that controls the ballast size.
-		os.Args = append(os.Args, "--mem-ballast-size-mib="+ballastSize)
-	}
-	log.Printf("Set ballast to %s MiB", ballastSize)
+	ballastSizeMiB, _ := strconv.Atoi(ballastSize)
+	log.Printf("Set ballast to %d MiB", ballastSizeMiB)
+	return ballastSizeMiB
 }
 
-// Validate and set the memory limit
-func setMemoryLimit(memTotalSizeMiB int) {
+// Validate and set the memory limit. Returns the resolved limit in MiB.
+func setMemoryLimit(memTotalSizeMiB, ballastSizeMiB int) int {
 	memLimit := 0
 	// Check if the memory limit is specified via the env var
 	memoryLimit := os.Getenv(memLimitMiBEnvVarName)
-	// If not, calculate it from memTotalSizeMiB
+	// If not, calculate it from memTotalSizeMiB, or the percentage env var if set
 	if memoryLimit == "" {
-		memLimit = memTotalSizeMiB * defaultMemoryLimitPercentage / 100
-		// The memory limit should be set to defaultMemoryLimitPercentage of total memory
-		// while reserving a maximum of defaultMemoryLimitMaxMiB of memory.
-		if (memTotalSizeMiB - memLimit) > defaultMemoryLimitMaxMiB {
-			memLimit = defaultMemoryLimitMaxMiB
+		if pct := os.Getenv(memLimitPercentageEnvVarName); pct != "" {
+			memLimit = percentageOfTotal(pct, memLimitPercentageEnvVarName, memTotalSizeMiB)
+		} else {
+			memLimit = memTotalSizeMiB * defaultMemoryLimitPercentage / 100
+			// The memory limit should be set to defaultMemoryLimitPercentage of total memory
+			// while reserving a maximum of defaultMemoryLimitMaxMiB of memory.
+			if (memTotalSizeMiB - memLimit) > defaultMemoryLimitMaxMiB {
+				memLimit = memTotalSizeMiB - defaultMemoryLimitMaxMiB
+			}
 		}
 	} else {
 		memLimit, _ = strconv.Atoi(memoryLimit)
 	}
 
 	// Validate memoryLimit is sane
-	args := os.Args[1:]
-	b := getKeyValue(args, "--mem-ballast-size-mib")
-	ballastSize, _ := strconv.Atoi(b)
-	if (ballastSize * 2) > memLimit {
-		log.Fatalf("Memory limit (%v) is less than 2x ballast (%v). Increase memory limit or decrease ballast size.", memLimit, ballastSize)
+	if (ballastSizeMiB * 2) > memLimit {
+		log.Fatalf("Memory limit (%v) is less than 2x ballast (%v). Increase memory limit or decrease ballast size.", memLimit, ballastSizeMiB)
 	}
 
 	os.Setenv(memLimitMiBEnvVarName, strconv.Itoa(memLimit))
 	log.Printf("Set memory limit to %d MiB", memLimit)
+	return memLimit
 }
 
 func runInteractive(params service.AppSettings) error {
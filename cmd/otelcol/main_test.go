@@ -0,0 +1,187 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupMemoryLimitMiBFromPaths(t *testing.T) {
+	writeFile := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "memory.limit")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		wantMiB   int
+		wantFound bool
+	}{
+		{
+			name:      "cgroup v2 explicit limit",
+			content:   "536870912\n", // 512 MiB
+			wantMiB:   512,
+			wantFound: true,
+		},
+		{
+			name:      "cgroup v2 unlimited",
+			content:   "max\n",
+			wantFound: false,
+		},
+		{
+			name:      "cgroup v1 explicit limit",
+			content:   "1073741824\n", // 1024 MiB
+			wantMiB:   1024,
+			wantFound: true,
+		},
+		{
+			name:      "cgroup v1 unlimited sentinel",
+			content:   "9223372036854771712\n",
+			wantFound: false,
+		},
+		{
+			name:      "empty file",
+			content:   "",
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.content)
+			gotMiB, gotFound := cgroupMemoryLimitMiBFromPaths([]string{path})
+			if gotFound != tt.wantFound {
+				t.Fatalf("cgroupMemoryLimitMiBFromPaths() found = %v, want %v", gotFound, tt.wantFound)
+			}
+			if gotFound && gotMiB != tt.wantMiB {
+				t.Fatalf("cgroupMemoryLimitMiBFromPaths() = %d MiB, want %d MiB", gotMiB, tt.wantMiB)
+			}
+		})
+	}
+
+	t.Run("falls through to next path", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "does-not-exist")
+		fallback := writeFile(t, "2147483648\n") // 2048 MiB
+		gotMiB, gotFound := cgroupMemoryLimitMiBFromPaths([]string{missing, fallback})
+		if !gotFound || gotMiB != 2048 {
+			t.Fatalf("cgroupMemoryLimitMiBFromPaths() = (%d, %v), want (2048, true)", gotMiB, gotFound)
+		}
+	})
+}
+
+func TestPercentageOfTotal(t *testing.T) {
+	tests := []struct {
+		name            string
+		pct             string
+		memTotalSizeMiB int
+		want            int
+	}{
+		{name: "33 percent of 1024", pct: "33", memTotalSizeMiB: 1024, want: 337},
+		{name: "0 percent", pct: "0", memTotalSizeMiB: 1024, want: 0},
+		{name: "100 percent", pct: "100", memTotalSizeMiB: 512, want: 512},
+		{name: "fractional percent", pct: "12.5", memTotalSizeMiB: 1000, want: 125},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percentageOfTotal(tt.pct, "SPLUNK_TEST_PERCENTAGE", tt.memTotalSizeMiB)
+			if got != tt.want {
+				t.Fatalf("percentageOfTotal(%q, _, %d) = %d, want %d", tt.pct, tt.memTotalSizeMiB, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		key  string
+		want []string
+	}{
+		{
+			name: "single equals form",
+			args: []string{"--config=/a.yaml"},
+			key:  "--config",
+			want: []string{"/a.yaml"},
+		},
+		{
+			name: "single space-separated form",
+			args: []string{"--config", "/a.yaml"},
+			key:  "--config",
+			want: []string{"/a.yaml"},
+		},
+		{
+			name: "repeated flag collects every occurrence in order",
+			args: []string{"--config=/a.yaml", "--config=/b.yaml", "--config", "/c.yaml"},
+			key:  "--config",
+			want: []string{"/a.yaml", "/b.yaml", "/c.yaml"},
+		},
+		{
+			name: "no matches",
+			args: []string{"--other=/a.yaml"},
+			key:  "--config",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getKeyValues(tt.args, tt.key)
+			if !equalPaths(got, tt.want) {
+				t.Fatalf("getKeyValues(%v, %q) = %v, want %v", tt.args, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitConfigList(t *testing.T) {
+	tests := []struct {
+		name string
+		val  string
+		want []string
+	}{
+		{name: "empty", val: "", want: nil},
+		{name: "single path", val: "/a.yaml", want: []string{"/a.yaml"}},
+		{name: "colon separated", val: "/a.yaml:/b.yaml", want: []string{"/a.yaml", "/b.yaml"}},
+		{name: "comma separated", val: "/a.yaml,/b.yaml", want: []string{"/a.yaml", "/b.yaml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitConfigList(tt.val)
+			if !equalPaths(got, tt.want) {
+				t.Fatalf("splitConfigList(%q) = %v, want %v", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDefaultConfigPath(t *testing.T) {
+	if !isDefaultConfigPath(defaultLocalSAPMConfig) {
+		t.Fatalf("expected %q to be a default config path", defaultLocalSAPMConfig)
+	}
+	if isDefaultConfigPath("/some/custom/config.yaml") {
+		t.Fatalf("expected a custom path to not be a default config path")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.opentelemetry.io/collector/config/configparser"
+	"go.opentelemetry.io/collector/service/parserprovider"
+)
+
+// newBaseParserProvider returns the innermost ParserProvider used for config
+// resolution: a provider that merges configPaths (in order, later paths
+// override earlier ones) with any SPLUNK_CONFIG_YAML content merged in last
+// as a final overlay, or parserprovider.NewInMemory when no config paths
+// were resolved (SPLUNK_CONFIG_YAML only).
+func newBaseParserProvider(configPaths []string, configYAML string) parserprovider.ParserProvider {
+	if len(configPaths) == 0 {
+		return parserprovider.NewInMemory(bytes.NewBufferString(configYAML))
+	}
+	return newMergingParserProvider(configPaths, configYAML)
+}
+
+// newMergingParserProvider returns a ParserProvider that loads each of paths
+// in order and deep-merges the resulting ConfigMaps, with later entries
+// overriding earlier ones. This lets operators layer a base gateway config
+// with a site- or tenant-specific overlay without templating. If yaml is
+// non-empty it is merged in last, after every path, so SPLUNK_CONFIG_YAML
+// always acts as the final overlay.
+func newMergingParserProvider(paths []string, yaml string) parserprovider.ParserProvider {
+	return &mergingParserProvider{paths: paths, yaml: yaml}
+}
+
+type mergingParserProvider struct {
+	paths []string
+	yaml  string
+}
+
+func (m *mergingParserProvider) Get() (*configparser.ConfigMap, error) {
+	merged := configparser.NewConfigMap()
+	for _, p := range m.paths {
+		cfgMap, err := configparser.NewConfigMapFromFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the configuration file %q: %w", p, err)
+		}
+		if err := merged.MergeStringMap(cfgMap.ToStringMap()); err != nil {
+			return nil, fmt.Errorf("unable to merge configuration file %q: %w", p, err)
+		}
+	}
+
+	if m.yaml != "" {
+		yamlMap, err := configparser.NewConfigMapFromBuffer(bytes.NewBufferString(m.yaml))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", configYamlEnvVarName, err)
+		}
+		if err := merged.MergeStringMap(yamlMap.ToStringMap()); err != nil {
+			return nil, fmt.Errorf("unable to merge %s: %w", configYamlEnvVarName, err)
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,70 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergingParserProviderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	overlay := filepath.Join(dir, "overlay.yaml")
+
+	writeYAML(t, base, "receivers:\n  otlp:\nexporters:\n  sapm:\n    endpoint: https://base\n")
+	writeYAML(t, overlay, "exporters:\n  sapm:\n    endpoint: https://overlay\n")
+
+	provider := newMergingParserProvider([]string{base, overlay}, "")
+	cfgMap, err := provider.Get()
+	if err != nil {
+		t.Fatalf("Get() returned an error: %v", err)
+	}
+
+	endpoint := cfgMap.Get("exporters::sapm::endpoint")
+	if endpoint != "https://overlay" {
+		t.Fatalf("exporters::sapm::endpoint = %v, want the overlay's value to win over the base's", endpoint)
+	}
+
+	if cfgMap.Get("receivers::otlp") == nil {
+		t.Fatalf("expected the base file's receivers::otlp to survive the merge")
+	}
+}
+
+func TestMergingParserProviderYAMLOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	writeYAML(t, base, "exporters:\n  sapm:\n    endpoint: https://base\n")
+
+	provider := newMergingParserProvider([]string{base}, "exporters:\n  sapm:\n    endpoint: https://yaml-env\n")
+	cfgMap, err := provider.Get()
+	if err != nil {
+		t.Fatalf("Get() returned an error: %v", err)
+	}
+
+	endpoint := cfgMap.Get("exporters::sapm::endpoint")
+	if endpoint != "https://yaml-env" {
+		t.Fatalf("exporters::sapm::endpoint = %v, want SPLUNK_CONFIG_YAML to be the final overlay", endpoint)
+	}
+}
+
+func writeYAML(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write fixture %q: %v", path, err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	// memorylimiterprocessor.Config here must stay in lockstep with the
+	// pre-v0.36 ParserProvider/ConfigMap API the rest of this package uses
+	// (ballast.go, mergeprovider.go) -- MemoryLimitMiB/MemorySpikeLimitMiB are
+	// the field names for the collector version this package is pinned to.
+	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
+	"go.opentelemetry.io/collector/service/parserprovider"
+)
+
+const validateCommand = "validate"
+
+// requiredExporterByConfigPath maps a bundled default config to the
+// exporter it is expected to configure, so validate can catch a config that
+// no longer ships one.
+var requiredExporterByConfigPath = map[string]string{
+	defaultDockerSAPMConfig: "sapm",
+	defaultLocalSAPMConfig:  "sapm",
+	defaultDockerOTLPConfig: "otlp",
+	defaultLocalOTLPConfig:  "otlp",
+}
+
+// isValidateCommand reports whether the user invoked `otelcol validate` (or
+// passed --dry-run), requesting a static check of the assembled
+// configuration instead of actually starting the collector.
+func isValidateCommand(args []string) bool {
+	if len(args) > 0 && args[0] == validateCommand {
+		return true
+	}
+	return contains(args, "--dry-run")
+}
+
+// validate statically assembles the collector configuration exactly as run
+// would, but instead of starting the service it runs config.Config.Validate
+// plus Splunk-specific sanity checks and reports the result without opening
+// any sockets or starting any components.
+func validate(factories component.Factories, info component.BuildInfo, parserProvider parserprovider.ParserProvider, configPaths []string, ballastSizeMiB, memLimitMiB int) error {
+	cfgMap, err := parserProvider.Get()
+	if err != nil {
+		return fmt.Errorf("failed to resolve configuration: %w", err)
+	}
+
+	cfg, err := config.Load(cfgMap, factories)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration is invalid: %w", err)
+	}
+
+	if err := validateMemoryLimiters(cfg, ballastSizeMiB, memLimitMiB); err != nil {
+		return err
+	}
+
+	if err := checkRequiredExporters(cfg, configPaths); err != nil {
+		return err
+	}
+
+	log.Printf("Configuration is valid")
+	return nil
+}
+
+// checkRequiredExporters confirms that, for every path in configPaths that
+// is one of Splunk's bundled default configs, the exporter it's expected to
+// ship is still present in cfg -- catching a default config that was edited
+// down to the point of no longer exporting anywhere.
+func checkRequiredExporters(cfg *config.Config, configPaths []string) error {
+	for _, p := range configPaths {
+		exporter, ok := requiredExporterByConfigPath[p]
+		if !ok {
+			continue
+		}
+		if _, ok := cfg.Exporters[exporter]; !ok {
+			return fmt.Errorf("default config %q is missing its required %q exporter", p, exporter)
+		}
+	}
+	return nil
+}
+
+// validateMemoryLimiters checks every memory_limiter processor (including
+// named instances, e.g. "memory_limiter/with-spike") against the resolved
+// ballast and memory limit: limit_mib must be at least 2x the ballast, and
+// spike_limit_mib, if set, must be smaller than limit_mib. A processor with
+// limit_mib unset is expected to fall back to SPLUNK_MEMORY_LIMIT_MIB, so
+// memLimitMiB is used in its place.
+func validateMemoryLimiters(cfg *config.Config, ballastSizeMiB, memLimitMiB int) error {
+	for name, proc := range cfg.Processors {
+		if !isMemoryLimiter(name) {
+			continue
+		}
+
+		mlCfg, ok := proc.(*memorylimiterprocessor.Config)
+		if !ok {
+			continue
+		}
+
+		limitMiB := int(mlCfg.MemoryLimitMiB)
+		if limitMiB == 0 {
+			limitMiB = memLimitMiB
+		}
+
+		if (ballastSizeMiB * 2) > limitMiB {
+			return fmt.Errorf("processors::%s limit_mib (%d) is less than 2x ballast (%d)", name, limitMiB, ballastSizeMiB)
+		}
+
+		if spikeLimitMiB := int(mlCfg.MemorySpikeLimitMiB); spikeLimitMiB > 0 && spikeLimitMiB >= limitMiB {
+			return fmt.Errorf("processors::%s spike_limit_mib (%d) must be less than limit_mib (%d)", name, spikeLimitMiB, limitMiB)
+		}
+	}
+	return nil
+}
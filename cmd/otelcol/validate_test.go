@@ -0,0 +1,119 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
+)
+
+func TestIsValidateCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "validate subcommand", args: []string{"validate"}, want: true},
+		{name: "dry-run flag", args: []string{"--config=/a.yaml", "--dry-run"}, want: true},
+		{name: "neither", args: []string{"--config=/a.yaml"}, want: false},
+		{name: "no args", args: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidateCommand(tt.args); got != tt.want {
+				t.Fatalf("isValidateCommand(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMemoryLimitersOK(t *testing.T) {
+	cfg := &config.Config{
+		Processors: map[string]config.Processor{
+			"memory_limiter": &memorylimiterprocessor.Config{MemoryLimitMiB: 512},
+			"batch":          &memorylimiterprocessor.Config{},
+		},
+	}
+
+	if err := validateMemoryLimiters(cfg, 128, 256); err != nil {
+		t.Fatalf("validateMemoryLimiters() returned an error: %v", err)
+	}
+}
+
+func TestValidateMemoryLimitersFallsBackToMemLimitMiB(t *testing.T) {
+	cfg := &config.Config{
+		Processors: map[string]config.Processor{
+			"memory_limiter": &memorylimiterprocessor.Config{},
+		},
+	}
+
+	if err := validateMemoryLimiters(cfg, 128, 256); err != nil {
+		t.Fatalf("validateMemoryLimiters() returned an error: %v", err)
+	}
+
+	if err := validateMemoryLimiters(cfg, 200, 256); err == nil {
+		t.Fatalf("validateMemoryLimiters() = nil error, want an error when 2x ballast exceeds the memLimitMiB fallback")
+	}
+}
+
+func TestValidateMemoryLimitersBallastConflict(t *testing.T) {
+	cfg := &config.Config{
+		Processors: map[string]config.Processor{
+			"memory_limiter": &memorylimiterprocessor.Config{MemoryLimitMiB: 128},
+		},
+	}
+
+	err := validateMemoryLimiters(cfg, 100, 256)
+	if err == nil {
+		t.Fatalf("validateMemoryLimiters() = nil error, want an error when limit_mib is less than 2x ballast")
+	}
+}
+
+func TestValidateMemoryLimitersSpikeLimit(t *testing.T) {
+	cfg := &config.Config{
+		Processors: map[string]config.Processor{
+			"memory_limiter/with-spike": &memorylimiterprocessor.Config{
+				MemoryLimitMiB:      512,
+				MemorySpikeLimitMiB: 512,
+			},
+		},
+	}
+
+	err := validateMemoryLimiters(cfg, 0, 0)
+	if err == nil {
+		t.Fatalf("validateMemoryLimiters() = nil error, want an error when spike_limit_mib >= limit_mib")
+	}
+}
+
+func TestCheckRequiredExporters(t *testing.T) {
+	withSAPM := &config.Config{Exporters: map[string]config.Exporter{"sapm": nil}}
+	withoutSAPM := &config.Config{Exporters: map[string]config.Exporter{"otlp": nil}}
+
+	if err := checkRequiredExporters(withSAPM, []string{defaultLocalSAPMConfig}); err != nil {
+		t.Fatalf("checkRequiredExporters() returned an error for a config that has the required exporter: %v", err)
+	}
+
+	if err := checkRequiredExporters(withoutSAPM, []string{defaultLocalSAPMConfig}); err == nil {
+		t.Fatalf("checkRequiredExporters() = nil error, want an error when the default config's required exporter is missing")
+	}
+
+	if err := checkRequiredExporters(withoutSAPM, []string{"/some/custom/config.yaml"}); err != nil {
+		t.Fatalf("checkRequiredExporters() returned an error for a non-default config path: %v", err)
+	}
+}